@@ -0,0 +1,31 @@
+package gost
+
+import "net/http"
+
+// AdminServer exposes optional operational endpoints for the chain —
+// currently just /metrics — separate from the proxy's data-path
+// listeners, so operators can scrape it without exposing it alongside
+// proxy traffic.
+type AdminServer struct {
+	Addr string
+	// Metrics is served at /metrics when it implements http.Handler
+	// (as ExpvarMetrics does). NewAdminServer sets it to the package's
+	// active collector; nil disables the endpoint.
+	Metrics Metrics
+}
+
+// NewAdminServer creates an admin server bound to addr, serving the
+// package's current metrics collector at /metrics.
+func NewAdminServer(addr string) *AdminServer {
+	return &AdminServer{Addr: addr, Metrics: GetMetrics()}
+}
+
+// ListenAndServe starts the admin HTTP server. It blocks until the
+// server stops or errors.
+func (s *AdminServer) ListenAndServe() error {
+	mux := http.NewServeMux()
+	if h, ok := s.Metrics.(http.Handler); ok {
+		mux.Handle("/metrics", h)
+	}
+	return http.ListenAndServe(s.Addr, mux)
+}
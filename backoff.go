@@ -0,0 +1,69 @@
+package gost
+
+import (
+	"math/rand"
+	"time"
+)
+
+// BackoffStrategy computes the delay to wait before a given retry
+// attempt (1-indexed: the delay before the first retry is
+// Duration(1)).
+type BackoffStrategy interface {
+	Duration(attempt int) time.Duration
+}
+
+// NoBackoff retries immediately, with no delay between attempts.
+type NoBackoff struct{}
+
+// Duration always returns zero.
+func (NoBackoff) Duration(attempt int) time.Duration {
+	return 0
+}
+
+// ConstantBackoff waits a fixed delay between every attempt.
+type ConstantBackoff struct {
+	Delay time.Duration
+}
+
+// Duration returns the configured fixed delay.
+func (b ConstantBackoff) Duration(attempt int) time.Duration {
+	return b.Delay
+}
+
+// ExponentialBackoff doubles the delay on each attempt, starting at
+// Base and capped at Max, with up to Jitter fraction of random noise
+// added to avoid synchronized retries across many clients.
+type ExponentialBackoff struct {
+	Base   time.Duration
+	Max    time.Duration
+	Jitter float64
+}
+
+// Duration returns min(Base*2^(attempt-1), Max), plus random jitter of
+// up to Jitter times that delay (Jitter is a fraction, e.g. 0.5 for 50%).
+func (b ExponentialBackoff) Duration(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	d := b.Base
+	if d <= 0 {
+		d = time.Second
+	}
+	max := b.Max
+	if max <= 0 {
+		max = 30 * time.Second
+	}
+
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+
+	if b.Jitter > 0 {
+		d += time.Duration(rand.Float64() * b.Jitter * float64(d))
+	}
+	return d
+}
@@ -0,0 +1,58 @@
+package gost
+
+import "testing"
+
+func TestNoBackoffIsAlwaysZero(t *testing.T) {
+	var b NoBackoff
+	for _, attempt := range []int{0, 1, 5} {
+		if d := b.Duration(attempt); d != 0 {
+			t.Fatalf("attempt %d: got %v, want 0", attempt, d)
+		}
+	}
+}
+
+func TestConstantBackoffReturnsDelayRegardlessOfAttempt(t *testing.T) {
+	b := ConstantBackoff{Delay: 200 * testDuration}
+	for _, attempt := range []int{1, 2, 10} {
+		if d := b.Duration(attempt); d != b.Delay {
+			t.Fatalf("attempt %d: got %v, want %v", attempt, d, b.Delay)
+		}
+	}
+}
+
+func TestExponentialBackoffDoublesUpToMax(t *testing.T) {
+	b := ExponentialBackoff{Base: 1 * testDuration, Max: 8 * testDuration}
+
+	want := []int64{1, 2, 4, 8, 8, 8}
+	for i, mult := range want {
+		attempt := i + 1
+		got := int64(b.Duration(attempt) / testDuration)
+		if got != mult {
+			t.Fatalf("attempt %d: got %dx base, want %dx", attempt, got, mult)
+		}
+	}
+}
+
+func TestExponentialBackoffClampsAttemptBelowOne(t *testing.T) {
+	b := ExponentialBackoff{Base: 1 * testDuration, Max: 8 * testDuration}
+	if got, want := b.Duration(0), b.Duration(1); got != want {
+		t.Fatalf("attempt 0 should behave like attempt 1, got %v want %v", got, want)
+	}
+}
+
+func TestExponentialBackoffJitterStaysWithinBound(t *testing.T) {
+	b := ExponentialBackoff{Base: 10 * testDuration, Max: 10 * testDuration, Jitter: 0.5}
+	base := 10 * testDuration
+
+	for i := 0; i < 50; i++ {
+		d := b.Duration(1)
+		if d < base || d > base+base/2 {
+			t.Fatalf("jittered duration %v out of bound [%v, %v]", d, base, base+base/2)
+		}
+	}
+}
+
+// testDuration is an arbitrary small unit used to keep the table-driven
+// assertions above readable as integer multiples rather than raw
+// time.Duration literals.
+const testDuration = 1_000_000 // 1ms, as time.Duration nanoseconds
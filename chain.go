@@ -1,24 +1,36 @@
 package gost
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"net"
-
-	"github.com/go-log/log"
+	"time"
 )
 
 var (
 	// ErrEmptyChain is an error that implies the chain is empty.
 	ErrEmptyChain = errors.New("empty chain")
+
+	errAttemptTimeout = errors.New("chain: attempt timeout")
 )
 
 // Chain is a proxy chain that holds a list of proxy nodes.
 type Chain struct {
-	isRoute    bool
-	Retries    int
+	isRoute bool
+	Retries int
+	// ID identifies the chain in structured logs. It is optional; an
+	// empty ID is logged as-is, as an empty chain= field.
+	ID         string
 	nodeGroups []*NodeGroup
+
+	// Backoff controls the delay between retry attempts in Dial and
+	// Conn. A Backoff set on any of the chain's node groups takes
+	// precedence; NoBackoff{} is used when neither is set.
+	Backoff BackoffStrategy
+	// AttemptTimeout bounds a single dial+handshake attempt, so one
+	// slow hop can't consume the entire retry budget. Zero means no
+	// per-attempt deadline.
+	AttemptTimeout time.Duration
 }
 
 // NewChain creates a proxy chain with a list of proxy nodes.
@@ -100,48 +112,138 @@ func (c *Chain) IsEmpty() bool {
 // Dial connects to the target address addr through the chain.
 // If the chain is empty, it will use the net.Dial directly.
 func (c *Chain) Dial(addr string) (conn net.Conn, err error) {
+	return c.DialWithKey(addr, "")
+}
+
+// DialWithKey connects to the target address addr through the chain,
+// using key as the affinity key for group strategies (such as
+// HashStrategy) that route a given key to the same node on every call.
+// If the chain is empty, it will use the net.Dial directly.
+func (c *Chain) DialWithKey(addr, key string) (conn net.Conn, err error) {
 	if c.IsEmpty() {
 		return net.DialTimeout("tcp", addr, DialTimeout)
 	}
 
-	for i := 0; i < c.Retries+1; i++ {
-		conn, err = c.dial(addr)
+	backoff := c.backoff()
+	for i := 0; i <= c.Retries; i++ {
+		if i > 0 {
+			time.Sleep(backoff.Duration(i))
+		}
+		conn, err = c.dialAttempt(addr, key, i+1)
 		if err == nil {
-			break
+			return
 		}
 	}
 	return
 }
 
-func (c *Chain) dial(addr string) (net.Conn, error) {
-	route, err := c.selectRoute()
+// dialAttempt runs a single dial attempt, bounded by AttemptTimeout
+// when set. attempt is the 1-indexed attempt number, reported in logs
+// and metrics so operators can see how many retries a dial needed.
+func (c *Chain) dialAttempt(addr, key string, attempt int) (net.Conn, error) {
+	return c.withAttemptTimeout(attempt, func() (net.Conn, error) {
+		return c.dial(addr, key, attempt)
+	})
+}
+
+// withAttemptTimeout bounds a single attempt of fn by AttemptTimeout,
+// so one slow hop can't consume the entire retry budget, in both the
+// Dial and Conn paths. It is a no-op wrapper when AttemptTimeout is
+// unset. On timeout, fn's result (if it arrives later) is drained and
+// its connection closed so it doesn't leak.
+func (c *Chain) withAttemptTimeout(attempt int, fn func() (net.Conn, error)) (net.Conn, error) {
+	if c.AttemptTimeout <= 0 {
+		return fn()
+	}
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		conn, err := fn()
+		ch <- result{conn, err}
+	}()
+
+	select {
+	case r := <-ch:
+		return r.conn, r.err
+	case <-time.After(c.AttemptTimeout):
+		go func() {
+			if r := <-ch; r.conn != nil {
+				r.conn.Close()
+			}
+		}()
+		logWarn("chain: attempt timed out", F("chain", c.ID), F("attempt", attempt))
+		return nil, errAttemptTimeout
+	}
+}
+
+// backoff returns the backoff strategy to use for retries: the first
+// node group with one configured takes precedence over the chain's own
+// setting, falling back to NoBackoff if neither is set.
+func (c *Chain) backoff() BackoffStrategy {
+	for _, group := range c.nodeGroups {
+		if group.Backoff != nil {
+			return group.Backoff
+		}
+	}
+	if c.Backoff == nil {
+		return NoBackoff{}
+	}
+	return c.Backoff
+}
+
+func (c *Chain) dial(addr, key string, attempt int) (net.Conn, error) {
+	start := time.Now()
+
+	route, err := c.selectRoute(key)
 	if err != nil {
+		c.reportDial("error", start)
 		return nil, err
 	}
 
-	conn, err := route.getConn()
+	conn, err := route.getConn(c.ID, attempt)
 	if err != nil {
+		c.reportDial("error", start)
 		return nil, err
 	}
 
 	cc, err := route.LastNode().Client.Connect(conn, addr)
 	if err != nil {
 		conn.Close()
+		c.reportDial("error", start)
 		return nil, err
 	}
+	c.reportDial("ok", start)
 	return cc, nil
 }
 
+// reportDial records a completed dial attempt's outcome and elapsed
+// time to the active metrics collector.
+func (c *Chain) reportDial(result string, start time.Time) {
+	GetMetrics().CounterAdd(metricChainDialTotal, map[string]string{"result": result}, 1)
+	GetMetrics().ObserveDuration(metricChainDialDuration, map[string]string{"hop": "total"}, time.Since(start))
+}
+
 // Conn obtains a handshaked connection to the last node of the chain.
 // If the chain is empty, it returns an ErrEmptyChain error.
 func (c *Chain) Conn() (conn net.Conn, err error) {
-	for i := 0; i < c.Retries+1; i++ {
-		var route *Chain
-		route, err = c.selectRoute()
-		if err != nil {
-			continue
+	backoff := c.backoff()
+	for i := 0; i <= c.Retries; i++ {
+		if i > 0 {
+			time.Sleep(backoff.Duration(i))
 		}
-		conn, err = route.getConn()
+
+		attempt := i + 1
+		conn, err = c.withAttemptTimeout(attempt, func() (net.Conn, error) {
+			route, rErr := c.selectRoute("")
+			if rErr != nil {
+				return nil, rErr
+			}
+			return route.getConn(c.ID, attempt)
+		})
 		if err != nil {
 			continue
 		}
@@ -151,7 +253,7 @@ func (c *Chain) Conn() (conn net.Conn, err error) {
 	return
 }
 
-func (c *Chain) getConn() (conn net.Conn, err error) {
+func (c *Chain) getConn(chainID string, attempt int) (conn net.Conn, err error) {
 	if c.IsEmpty() {
 		err = ErrEmptyChain
 		return
@@ -159,35 +261,53 @@ func (c *Chain) getConn() (conn net.Conn, err error) {
 	nodes := c.Nodes()
 	node := nodes[0]
 
+	start := time.Now()
+	node.arm()
 	cn, err := node.Client.Dial(node.Addr, node.DialOptions...)
 	if err != nil {
 		node.MarkDead()
+		logWarn("chain: hop dial failed", F("chain", chainID), F("hop", 0), F("node", node.Addr), F("attempt", attempt), F("error", err))
+		GetMetrics().CounterAdd(metricNodeHandshakeErrors, map[string]string{"node": node.Addr}, 1)
 		return
 	}
 
 	cn, err = node.Client.Handshake(cn, node.HandshakeOptions...)
 	if err != nil {
 		node.MarkDead()
+		logWarn("chain: hop handshake failed", F("chain", chainID), F("hop", 0), F("node", node.Addr), F("attempt", attempt), F("error", err))
+		GetMetrics().CounterAdd(metricNodeHandshakeErrors, map[string]string{"node": node.Addr}, 1)
 		return
 	}
 	node.ResetDead()
+	logDebug("chain: hop connected", F("chain", chainID), F("hop", 0), F("node", node.Addr), F("attempt", attempt), F("elapsed_ms", time.Since(start).Milliseconds()))
+	GetMetrics().ObserveDuration(metricChainDialDuration, map[string]string{"hop": "0"}, time.Since(start))
 
 	preNode := node
-	for _, node := range nodes[1:] {
+	for i, node := range nodes[1:] {
+		hop := i + 1
+		hopStart := time.Now()
+
 		var cc net.Conn
+		node.arm()
 		cc, err = preNode.Client.Connect(cn, node.Addr)
 		if err != nil {
 			cn.Close()
 			node.MarkDead()
+			logWarn("chain: hop connect failed", F("chain", chainID), F("hop", hop), F("node", node.Addr), F("attempt", attempt), F("error", err))
+			GetMetrics().CounterAdd(metricNodeHandshakeErrors, map[string]string{"node": node.Addr}, 1)
 			return
 		}
 		cc, err = node.Client.Handshake(cc, node.HandshakeOptions...)
 		if err != nil {
 			cn.Close()
 			node.MarkDead()
+			logWarn("chain: hop handshake failed", F("chain", chainID), F("hop", hop), F("node", node.Addr), F("attempt", attempt), F("error", err))
+			GetMetrics().CounterAdd(metricNodeHandshakeErrors, map[string]string{"node": node.Addr}, 1)
 			return
 		}
 		node.ResetDead()
+		logDebug("chain: hop connected", F("chain", chainID), F("hop", hop), F("node", node.Addr), F("attempt", attempt), F("elapsed_ms", time.Since(hopStart).Milliseconds()))
+		GetMetrics().ObserveDuration(metricChainDialDuration, map[string]string{"hop": fmt.Sprint(hop)}, time.Since(hopStart))
 
 		cn = cc
 		preNode = node
@@ -197,21 +317,21 @@ func (c *Chain) getConn() (conn net.Conn, err error) {
 	return
 }
 
-func (c *Chain) selectRoute() (route *Chain, err error) {
+func (c *Chain) selectRoute(key string) (route *Chain, err error) {
 	if c.isRoute {
 		return c, nil
 	}
 
-	buf := bytes.Buffer{}
 	route = newRoute()
 	route.Retries = c.Retries
 
-	for _, group := range c.nodeGroups {
-		node, err := group.Next()
+	for i, group := range c.nodeGroups {
+		node, err := group.NextWithKey(key)
 		if err != nil {
+			logWarn("chain: select route failed", F("chain", c.ID), F("hop", i), F("error", err))
 			return nil, err
 		}
-		buf.WriteString(fmt.Sprintf("%s -> ", node.String()))
+		logDebug("chain: select route hop", F("chain", c.ID), F("hop", i), F("node", node.Addr), F("transport", node.Transport))
 
 		if node.Client.Transporter.Multiplex() {
 			node.DialOptions = append(node.DialOptions,
@@ -223,8 +343,5 @@ func (c *Chain) selectRoute() (route *Chain, err error) {
 
 		route.AddNode(node)
 	}
-	if Debug {
-		log.Log("select route:", buf.String())
-	}
 	return
 }
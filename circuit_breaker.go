@@ -0,0 +1,193 @@
+package gost
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitState describes the health of a node as tracked by its
+// circuit breaker.
+type CircuitState int
+
+const (
+	// StateClosed means the node is healthy and eligible for selection.
+	StateClosed CircuitState = iota
+	// StateOpen means the node has exceeded its error threshold and is
+	// excluded from selection until the cooldown elapses.
+	StateOpen
+	// StateHalfOpen means the cooldown has elapsed and a single probe
+	// is being admitted to test whether the node has recovered.
+	StateHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// minCooldown is the initial cooldown applied the first time a node's
+// breaker trips; it doubles on each subsequent trip up to maxCooldown.
+const (
+	minCooldown = 5 * time.Second
+	maxCooldown = 2 * time.Minute
+)
+
+// nodeBreaker is a per-node circuit breaker. A node is opened after
+// maxErrors consecutive failures and excluded from selection for a
+// cooldown period that grows exponentially on repeated trips.
+type nodeBreaker struct {
+	mu       sync.Mutex
+	maxErrors int
+	errors    int
+	state     CircuitState
+	cooldown  time.Duration
+	openUntil time.Time
+	probing   bool
+}
+
+func newNodeBreaker(maxErrors int) *nodeBreaker {
+	return &nodeBreaker{
+		maxErrors: maxErrors,
+		cooldown:  minCooldown,
+	}
+}
+
+// canConsider reports whether the node is a valid candidate for
+// selection, without committing it as the probe. It must not mutate
+// state: it runs once per node on every Next()/NextWithKey() call
+// while building the candidate list, so only the node the selector
+// actually picks may be armed as the HalfOpen probe (see
+// admitSelected). A breaker with maxErrors <= 0 is disabled and always
+// considers the node.
+func (b *nodeBreaker) canConsider() bool {
+	if b == nil || b.maxErrors <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen, StateHalfOpen:
+		if b.probing {
+			// a probe is already in flight; don't pile on more until
+			// it resolves via fail()/succeed().
+			return false
+		}
+		return !time.Now().Before(b.openUntil)
+	default:
+		return true
+	}
+}
+
+// admitSelected commits the node as the in-flight HalfOpen probe. It
+// must only be called immediately before the node is actually dialed
+// (see Node.arm), never at selection time — a node chosen for a hop
+// that's never reached, because an earlier hop in the same route
+// failed first, would otherwise be stuck "probing" forever, since
+// only a real dial (via MarkDead/ResetDead) clears it.
+func (b *nodeBreaker) admitSelected() {
+	if b == nil || b.maxErrors <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen && !time.Now().Before(b.openUntil) {
+		b.state = StateHalfOpen
+		b.probing = true
+	}
+}
+
+func (b *nodeBreaker) fail() {
+	if b == nil || b.maxErrors <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	b.errors++
+
+	if b.state == StateHalfOpen {
+		// the probe failed: re-open with a longer cooldown.
+		b.cooldown *= 2
+		if b.cooldown > maxCooldown {
+			b.cooldown = maxCooldown
+		}
+		b.open()
+		return
+	}
+
+	if b.errors >= b.maxErrors {
+		b.open()
+	}
+}
+
+func (b *nodeBreaker) succeed() {
+	if b == nil || b.maxErrors <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	b.errors = 0
+	b.state = StateClosed
+	b.cooldown = minCooldown
+}
+
+// open transitions the breaker to StateOpen; callers must hold b.mu.
+func (b *nodeBreaker) open() {
+	b.state = StateOpen
+	b.openUntil = time.Now().Add(b.cooldown)
+}
+
+func (b *nodeBreaker) snapshot() NodeStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return NodeStatus{
+		State:    b.state,
+		Errors:   b.errors,
+		OpenUntil: b.openUntil,
+	}
+}
+
+// NodeStatus is a snapshot of a node's circuit-breaker state, returned
+// by NodeGroup.Status for operator inspection.
+type NodeStatus struct {
+	Addr      string
+	State     CircuitState
+	Errors    int
+	OpenUntil time.Time
+}
+
+// Status returns the circuit-breaker state of every node in the group,
+// so operators can see which nodes are currently excluded and why.
+func (g *NodeGroup) Status() []NodeStatus {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	status := make([]NodeStatus, 0, len(g.nodes))
+	for _, node := range g.nodes {
+		b := g.breakers[node.Addr]
+		if b == nil {
+			status = append(status, NodeStatus{Addr: node.Addr, State: StateClosed})
+			continue
+		}
+		s := b.snapshot()
+		s.Addr = node.Addr
+		status = append(status, s)
+	}
+	return status
+}
@@ -0,0 +1,137 @@
+package gost
+
+import (
+	"testing"
+	"time"
+)
+
+// TestNodeBreakerCanConsiderDoesNotArmUnselectedNodes guards against the
+// regression where merely building the candidate list (as
+// NodeGroup.availableNodes does on every Next call, for every node)
+// armed the HalfOpen probe as a side effect. canConsider must be safe
+// to call repeatedly without committing the node as the probe.
+func TestNodeBreakerCanConsiderDoesNotArmUnselectedNodes(t *testing.T) {
+	b := newNodeBreaker(1)
+	b.fail() // trips the breaker open at the 1-error threshold.
+	if b.state != StateOpen {
+		t.Fatalf("expected breaker to be open after reaching the error threshold, got %v", b.state)
+	}
+	b.openUntil = time.Now().Add(-time.Second) // cooldown has elapsed
+
+	for i := 0; i < 5; i++ {
+		if !b.canConsider() {
+			t.Fatalf("iteration %d: node should remain a valid candidate until it is actually probed", i)
+		}
+	}
+	if b.probing {
+		t.Fatalf("canConsider must not arm the probe as a side effect")
+	}
+}
+
+// TestNodeBreakerHalfOpenProbeLifecycle checks the probe is armed only
+// when the node is actually selected (admitSelected), and that a
+// resolved probe (success or failure) clears it correctly.
+func TestNodeBreakerHalfOpenProbeLifecycle(t *testing.T) {
+	b := newNodeBreaker(1)
+	b.fail()
+	b.openUntil = time.Now().Add(-time.Second)
+
+	b.admitSelected()
+	if b.state != StateHalfOpen || !b.probing {
+		t.Fatalf("expected the selected node to be armed as the HalfOpen probe")
+	}
+	if b.canConsider() {
+		t.Fatalf("a second caller must not be admitted while a probe is in flight")
+	}
+
+	b.succeed()
+	if b.state != StateClosed || b.probing {
+		t.Fatalf("a successful probe should close the breaker and clear probing")
+	}
+	if !b.canConsider() {
+		t.Fatalf("node should be selectable again after a successful probe")
+	}
+}
+
+// TestNodeGroupNextWithKeyDoesNotArmProbe guards against the regression
+// where NextWithKey itself armed the HalfOpen probe for the node it
+// returned. In a multi-hop chain, selectRoute calls NextWithKey on
+// every hop's group before any hop is actually dialed, so a later hop
+// can be selected and then never reached because an earlier hop
+// failed first. If selection alone armed the probe, that later hop's
+// node would be stuck "probing" forever. Only Node.arm, called from
+// Chain.getConn right before the real dial, may do that.
+func TestNodeGroupNextWithKeyDoesNotArmProbe(t *testing.T) {
+	group := NewNodeGroup(NewNode("a:1"))
+	group.SetMaxNodeErrors(1)
+
+	group.onNodeFailure("a:1")
+	group.mu.RLock()
+	breaker := group.breakers["a:1"]
+	group.mu.RUnlock()
+	breaker.openUntil = time.Now().Add(-time.Second) // cooldown elapsed
+
+	node, err := group.NextWithKey("")
+	if err != nil {
+		t.Fatalf("NextWithKey: %v", err)
+	}
+	if node.Addr != "a:1" {
+		t.Fatalf("expected a:1 to be selected once its cooldown elapsed, got %q", node.Addr)
+	}
+	if breaker.probing {
+		t.Fatalf("NextWithKey must not arm the probe by itself; only Node.arm, called at actual dial time, may")
+	}
+
+	// Since the node was never dialed, it must still be selectable
+	// instead of being stuck behind a probe that will never resolve.
+	node2, err := group.NextWithKey("")
+	if err != nil {
+		t.Fatalf("NextWithKey (second call): %v", err)
+	}
+	if node2.Addr != "a:1" {
+		t.Fatalf("a:1 should remain selectable when its probe was never armed, got %q", node2.Addr)
+	}
+
+	// Only once the node is actually about to be dialed does arm
+	// commit it as the in-flight probe.
+	node.arm()
+	if !breaker.probing {
+		t.Fatalf("expected arm to commit the node as the in-flight HalfOpen probe")
+	}
+	node.ResetDead()
+	if breaker.probing {
+		t.Fatalf("a resolved probe must clear probing")
+	}
+}
+
+// TestNodeGroupNodeRecoversAfterCooldown is the end-to-end case from
+// the spec: in a multi-node group, a node that tripped its breaker
+// must become selectable again once its cooldown elapses, not be
+// excluded forever just because other Next() calls considered and
+// passed over it.
+func TestNodeGroupNodeRecoversAfterCooldown(t *testing.T) {
+	group := NewNodeGroup(NewNode("a:1"), NewNode("b:1"))
+	group.SetMaxNodeErrors(1)
+	group.SetStrategy(RoundRobinStrategy)
+
+	group.onNodeFailure("a:1")
+	group.mu.RLock()
+	breaker := group.breakers["a:1"]
+	group.mu.RUnlock()
+	breaker.openUntil = time.Now().Add(-time.Second)
+
+	seenA := false
+	for i := 0; i < 4; i++ {
+		node, err := group.Next()
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if node.Addr == "a:1" {
+			seenA = true
+			node.ResetDead() // simulate the probe dial succeeding
+		}
+	}
+	if !seenA {
+		t.Fatalf("node a:1 should become selectable again once its cooldown elapses, not be excluded forever")
+	}
+}
@@ -0,0 +1,48 @@
+package gost
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-log/log"
+)
+
+// Field is a single structured key/value pair attached to a log line.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F is shorthand for constructing a Field.
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// logDebug writes msg with the given fields when Debug is enabled.
+// It replaces the old free-form "select route:" print with a
+// structured, leveled line operators can grep by field.
+func logDebug(msg string, fields ...Field) {
+	if !Debug {
+		return
+	}
+	log.Log(formatFields(msg, fields))
+}
+
+// logWarn writes msg with the given fields regardless of Debug, since
+// dial/handshake failures on the chain path are operationally relevant
+// even outside debug mode.
+func logWarn(msg string, fields ...Field) {
+	log.Log(formatFields(msg, fields))
+}
+
+func formatFields(msg string, fields []Field) string {
+	if len(fields) == 0 {
+		return msg
+	}
+	parts := make([]string, 0, len(fields)+1)
+	parts = append(parts, msg)
+	for _, f := range fields {
+		parts = append(parts, fmt.Sprintf("%s=%v", f.Key, f.Value))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,119 @@
+package gost
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metrics is the pluggable collector the chain dial path reports to.
+// The zero-value-friendly default publishes in-memory counters served
+// in a Prometheus-text-compatible format; set a different
+// implementation via SetMetrics to forward to another collector.
+type Metrics interface {
+	CounterAdd(name string, labels map[string]string, delta float64)
+	ObserveDuration(name string, labels map[string]string, d time.Duration)
+	GaugeSet(name string, labels map[string]string, value float64)
+}
+
+var (
+	metricsMu     sync.RWMutex
+	activeMetrics Metrics = NewExpvarMetrics()
+)
+
+// SetMetrics replaces the package's active metrics collector.
+func SetMetrics(m Metrics) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	activeMetrics = m
+}
+
+// GetMetrics returns the package's active metrics collector.
+func GetMetrics() Metrics {
+	metricsMu.RLock()
+	defer metricsMu.RUnlock()
+	return activeMetrics
+}
+
+// ExpvarMetrics is a minimal in-memory Metrics implementation. It
+// requires no external dependency and renders its current values as
+// Prometheus-exposition-style "name{labels} value" lines, suitable for
+// mounting at /metrics on the admin listener.
+type ExpvarMetrics struct {
+	mu   sync.Mutex
+	vars map[string]float64
+}
+
+// NewExpvarMetrics creates an empty ExpvarMetrics collector.
+func NewExpvarMetrics() *ExpvarMetrics {
+	return &ExpvarMetrics{vars: make(map[string]float64)}
+}
+
+func metricKey(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	key := name + "{"
+	for i, k := range keys {
+		if i > 0 {
+			key += ","
+		}
+		key += fmt.Sprintf("%s=%q", k, labels[k])
+	}
+	return key + "}"
+}
+
+// CounterAdd increments the named counter by delta.
+func (m *ExpvarMetrics) CounterAdd(name string, labels map[string]string, delta float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.vars[metricKey(name, labels)] += delta
+}
+
+// ObserveDuration records d under name as a sum/count pair, the
+// simplest possible histogram.
+func (m *ExpvarMetrics) ObserveDuration(name string, labels map[string]string, d time.Duration) {
+	m.CounterAdd(name+"_sum", labels, d.Seconds())
+	m.CounterAdd(name+"_count", labels, 1)
+}
+
+// GaugeSet sets the named gauge to value.
+func (m *ExpvarMetrics) GaugeSet(name string, labels map[string]string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.vars[metricKey(name, labels)] = value
+}
+
+// ServeHTTP renders the current metrics. Mount it at /metrics on the
+// admin/API listener to let operators scrape the chain's health.
+func (m *ExpvarMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]string, 0, len(m.vars))
+	for k := range m.vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s %v\n", k, m.vars[k])
+	}
+}
+
+// Metric names reported along the chain dial path and by node groups.
+const (
+	metricChainDialTotal      = "gost_chain_dial_total"
+	metricChainDialDuration   = "gost_chain_dial_duration_seconds"
+	metricNodeHandshakeErrors = "gost_node_handshake_failures_total"
+	metricNodeMarkedDead      = "gost_node_marked_dead_total"
+	metricGroupLiveNodes      = "gost_group_live_nodes"
+)
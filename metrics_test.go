@@ -0,0 +1,85 @@
+package gost
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMetricKeyWithoutLabels(t *testing.T) {
+	if got, want := metricKey("gost_chain_dial_total", nil), "gost_chain_dial_total"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestMetricKeySortsLabelsForStableOutput(t *testing.T) {
+	labels := map[string]string{"result": "ok", "chain": "c1"}
+	want := `gost_chain_dial_total{chain="c1",result="ok"}`
+	if got := metricKey("gost_chain_dial_total", labels); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestExpvarMetricsCounterAddAccumulates(t *testing.T) {
+	m := NewExpvarMetrics()
+	labels := map[string]string{"node": "a:1"}
+
+	m.CounterAdd("gost_node_marked_dead_total", labels, 1)
+	m.CounterAdd("gost_node_marked_dead_total", labels, 2)
+
+	if got, want := m.vars[metricKey("gost_node_marked_dead_total", labels)], 3.0; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestExpvarMetricsGaugeSetOverwrites(t *testing.T) {
+	m := NewExpvarMetrics()
+	labels := map[string]string{"group": "g1"}
+
+	m.GaugeSet(metricGroupLiveNodes, labels, 2)
+	m.GaugeSet(metricGroupLiveNodes, labels, 5)
+
+	if got, want := m.vars[metricKey(metricGroupLiveNodes, labels)], 5.0; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestExpvarMetricsObserveDurationRecordsSumAndCount(t *testing.T) {
+	m := NewExpvarMetrics()
+	labels := map[string]string{"hop": "0"}
+
+	m.ObserveDuration(metricChainDialDuration, labels, 500*time.Millisecond)
+	m.ObserveDuration(metricChainDialDuration, labels, 500*time.Millisecond)
+
+	if got, want := m.vars[metricKey(metricChainDialDuration+"_sum", labels)], 1.0; got != want {
+		t.Fatalf("sum: got %v, want %v", got, want)
+	}
+	if got, want := m.vars[metricKey(metricChainDialDuration+"_count", labels)], 2.0; got != want {
+		t.Fatalf("count: got %v, want %v", got, want)
+	}
+}
+
+func TestExpvarMetricsServeHTTPRendersSortedLines(t *testing.T) {
+	m := NewExpvarMetrics()
+	m.CounterAdd("b_total", nil, 1)
+	m.CounterAdd("a_total", nil, 1)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	want := "a_total 1\nb_total 1\n"
+	if got := rec.Body.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestGetSetMetricsRoundTrips(t *testing.T) {
+	orig := GetMetrics()
+	defer SetMetrics(orig)
+
+	m := NewExpvarMetrics()
+	SetMetrics(m)
+	if GetMetrics() != Metrics(m) {
+		t.Fatalf("GetMetrics did not return the collector set by SetMetrics")
+	}
+}
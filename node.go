@@ -0,0 +1,242 @@
+package gost
+
+import (
+	"fmt"
+	"math/rand"
+	"net/url"
+	"sync"
+)
+
+// Node is a proxy node, it describes a single hop in a proxy Chain.
+type Node struct {
+	Addr             string
+	Protocol         string
+	Transport        string
+	Remote           string
+	User             *url.Userinfo
+	Values           url.Values
+	Client           *Client
+	DialOptions      []DialOption
+	HandshakeOptions []HandshakeOption
+
+	group *NodeGroup
+}
+
+// NewNode creates a proxy node.
+func NewNode(addr string) Node {
+	return Node{Addr: addr}
+}
+
+// String returns the node's string representation.
+func (node *Node) String() string {
+	return fmt.Sprintf("%s+%s://%s", node.Protocol, node.Transport, node.Addr)
+}
+
+// Clone creates a copy of the node.
+func (node *Node) Clone() Node {
+	nd := *node
+	return nd
+}
+
+// MarkDead reports that a dial, handshake or connect through the node
+// just failed, feeding its group's circuit breaker.
+func (node *Node) MarkDead() {
+	if node.group != nil {
+		node.group.onNodeFailure(node.Addr)
+	}
+}
+
+// ResetDead reports that the node was just used successfully, closing
+// its group's circuit breaker.
+func (node *Node) ResetDead() {
+	if node.group != nil {
+		node.group.onNodeSuccess(node.Addr)
+	}
+}
+
+// arm commits the node as its group's in-flight HalfOpen probe. It
+// must be called immediately before the node is actually dialed (see
+// Chain.getConn), never while merely building a candidate list or a
+// multi-hop route — otherwise a node picked for a later hop that's
+// never reached (because an earlier hop failed first) would be armed
+// and then never resolved by a MarkDead/ResetDead call, excluding it
+// forever.
+func (node *Node) arm() {
+	if node.group != nil {
+		node.group.armProbe(node.Addr)
+	}
+}
+
+// Selector selects one node from a set of candidate nodes.
+type Selector interface {
+	Select(nodes []Node, key string) (Node, error)
+}
+
+// NodeGroup is a group of proxy nodes that can be used interchangeably,
+// e.g. for load balancing across a set of equivalent hops.
+type NodeGroup struct {
+	mu       sync.RWMutex
+	nodes    []Node
+	selector Selector
+
+	// ID identifies the group in metrics (e.g. the live-node gauge).
+	// It is optional; an empty ID is reported as-is.
+	ID string
+
+	// MaxNodeErrors is the number of consecutive failures that must occur
+	// before a node is excluded from selection. Zero disables the breaker.
+	MaxNodeErrors int
+
+	// Backoff, when set, overrides the chain's backoff strategy for all
+	// of the chain's retries. If several groups in a chain set one, the
+	// first such group in chain order wins.
+	Backoff BackoffStrategy
+
+	breakers map[string]*nodeBreaker
+}
+
+// NewNodeGroup creates a node group with the given nodes.
+// By default nodes are selected at random.
+func NewNodeGroup(nodes ...Node) *NodeGroup {
+	group := &NodeGroup{
+		selector:      new(randomSelector),
+		MaxNodeErrors: 3,
+		breakers:      make(map[string]*nodeBreaker),
+	}
+	group.AddNode(nodes...)
+	return group
+}
+
+// AddNode appends node(s) to the group.
+func (g *NodeGroup) AddNode(nodes ...Node) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, node := range nodes {
+		node.group = g
+		g.nodes = append(g.nodes, node)
+		g.breakers[node.Addr] = newNodeBreaker(g.MaxNodeErrors)
+	}
+}
+
+// Nodes returns the nodes held by the group.
+func (g *NodeGroup) Nodes() []Node {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	nodes := make([]Node, len(g.nodes))
+	copy(nodes, g.nodes)
+	return nodes
+}
+
+// SetMaxNodeErrors configures the consecutive-failure threshold that
+// trips a node's circuit breaker. It takes effect for nodes added
+// afterwards as well as those already in the group.
+func (g *NodeGroup) SetMaxNodeErrors(n int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.MaxNodeErrors = n
+	for _, node := range g.nodes {
+		g.breakers[node.Addr] = newNodeBreaker(n)
+	}
+}
+
+// SetSelector sets the node selection strategy for the group.
+func (g *NodeGroup) SetSelector(selector Selector) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.selector = selector
+}
+
+// Next returns the next available node in the group, skipping nodes
+// whose circuit breaker is currently open.
+func (g *NodeGroup) Next() (Node, error) {
+	return g.NextWithKey("")
+}
+
+// NextWithKey returns the next available node in the group according
+// to the group's selection strategy, skipping nodes whose circuit
+// breaker is currently open. The key is only consulted by
+// key-affinity strategies such as HashStrategy; it is ignored
+// otherwise.
+func (g *NodeGroup) NextWithKey(key string) (Node, error) {
+	nodes := g.availableNodes()
+	g.mu.RLock()
+	selector := g.selector
+	g.mu.RUnlock()
+
+	return selector.Select(nodes, key)
+}
+
+// armProbe commits addr as the group's in-flight HalfOpen probe. It is
+// called via Node.arm, right before the node is actually dialed, never
+// at selection time: a node merely chosen for a route isn't guaranteed
+// to be reached, since an earlier hop in the same route can still fail
+// first.
+func (g *NodeGroup) armProbe(addr string) {
+	g.mu.RLock()
+	b := g.breakers[addr]
+	g.mu.RUnlock()
+	if b != nil {
+		b.admitSelected()
+	}
+}
+
+// availableNodes returns the nodes that are currently valid candidates
+// for selection, i.e. not excluded by their circuit breaker.
+func (g *NodeGroup) availableNodes() []Node {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	nodes := make([]Node, 0, len(g.nodes))
+	for _, node := range g.nodes {
+		if b := g.breakers[node.Addr]; b == nil || b.canConsider() {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+func (g *NodeGroup) onNodeFailure(addr string) {
+	g.mu.RLock()
+	b := g.breakers[addr]
+	g.mu.RUnlock()
+	if b != nil {
+		b.fail()
+	}
+	GetMetrics().CounterAdd(metricNodeMarkedDead, map[string]string{"node": addr}, 1)
+	g.reportLiveNodes()
+}
+
+func (g *NodeGroup) onNodeSuccess(addr string) {
+	g.mu.RLock()
+	b := g.breakers[addr]
+	g.mu.RUnlock()
+	if b != nil {
+		b.succeed()
+	}
+	g.reportLiveNodes()
+}
+
+// reportLiveNodes publishes the group's current count of non-excluded
+// nodes as a gauge, so operators can see the health of a group at a
+// glance instead of reading per-node breaker state.
+func (g *NodeGroup) reportLiveNodes() {
+	GetMetrics().GaugeSet(metricGroupLiveNodes, map[string]string{"group": g.ID}, float64(len(g.availableNodes())))
+}
+
+var (
+	// ErrNoAvailableNode is returned by NodeGroup.Next when every node
+	// in the group is excluded by its circuit breaker.
+	ErrNoAvailableNode = fmt.Errorf("no available node")
+)
+
+type randomSelector struct{}
+
+func (s *randomSelector) Select(nodes []Node, key string) (Node, error) {
+	if len(nodes) == 0 {
+		return Node{}, ErrNoAvailableNode
+	}
+	return nodes[rand.Intn(len(nodes))], nil
+}
@@ -0,0 +1,100 @@
+package gost
+
+import (
+	"errors"
+	"io"
+	"net"
+)
+
+// ErrRedirectNotSupported is returned when transparent redirection is
+// requested on a platform (or socket type) gost doesn't know how to
+// recover the original destination for.
+var ErrRedirectNotSupported = errors.New("transparent redirect: not supported on this platform")
+
+// TCPRedirectListener accepts TCP connections redirected by an iptables
+// REDIRECT (or equivalent) rule. It does not resolve the original
+// destination itself; TCPRedirectHandler does that for each accepted
+// connection, via SO_ORIGINAL_DST (Linux) or DIOCNATLOOK (BSD/macOS).
+type TCPRedirectListener struct {
+	net.Listener
+}
+
+// NewTCPRedirectListener creates a TCPRedirectListener bound to addr.
+func NewTCPRedirectListener(addr string) (*TCPRedirectListener, error) {
+	laddr, err := net.ResolveTCPAddr("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	ln, err := net.ListenTCP("tcp", laddr)
+	if err != nil {
+		return nil, err
+	}
+	return &TCPRedirectListener{Listener: ln}, nil
+}
+
+// TCPRedirectHandler resolves the original destination of a redirected
+// connection and forwards the stream through a Chain.
+type TCPRedirectHandler struct {
+	Chain *Chain
+
+	// SpoofSource dials the upstream from the client's original
+	// source address when the chain is empty, so the final hop sees
+	// the real client IP instead of the gateway's. It has no effect
+	// when Chain is non-empty, since the chain's first hop must be
+	// reachable from the gateway's own address.
+	SpoofSource bool
+}
+
+// NewTCPRedirectHandler creates a handler that forwards redirected
+// connections through chain. A nil or empty chain dials the original
+// destination directly.
+func NewTCPRedirectHandler(chain *Chain) *TCPRedirectHandler {
+	return &TCPRedirectHandler{Chain: chain}
+}
+
+// Handle resolves the original destination of conn and relays the
+// stream to it through the handler's chain.
+func (h *TCPRedirectHandler) Handle(conn net.Conn) {
+	defer conn.Close()
+
+	tc, ok := conn.(*net.TCPConn)
+	if !ok {
+		logWarn("redirect: not a TCP connection")
+		return
+	}
+
+	addr, err := getOriginalDST(tc)
+	if err != nil {
+		logWarn("redirect: get original destination failed", F("error", err))
+		return
+	}
+
+	var cc net.Conn
+	if h.Chain.IsEmpty() && h.SpoofSource {
+		cc, err = dialSpoofed(tc.RemoteAddr(), addr)
+	} else {
+		cc, err = h.Chain.Dial(addr)
+	}
+	if err != nil {
+		logWarn("redirect: dial failed", F("addr", addr), F("error", err))
+		return
+	}
+	defer cc.Close()
+
+	relay(conn, cc)
+}
+
+// relay copies data in both directions between two connections until
+// either side closes or errors.
+func relay(a, b net.Conn) {
+	errc := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(a, b)
+		errc <- err
+	}()
+	go func() {
+		_, err := io.Copy(b, a)
+		errc <- err
+	}()
+	<-errc
+}
@@ -0,0 +1,89 @@
+//go:build darwin || freebsd
+// +build darwin freebsd
+
+package gost
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// pfAddr mirrors struct pf_addr (a bare 128-bit address union; only the
+// first 4 bytes are used for IPv4).
+type pfAddr struct {
+	addr [16]byte
+}
+
+// pfiocNatlook mirrors struct pfioc_natlook from <net/pfvar.h>, used
+// with DIOCNATLOOK to recover a redirected connection's original
+// destination from the pf state table.
+type pfiocNatlook struct {
+	saddr   pfAddr
+	daddr   pfAddr
+	rsaddr  pfAddr
+	rdaddr  pfAddr
+	sport   uint16
+	dport   uint16
+	rsport  uint16
+	rdport  uint16
+	af      uint8
+	proto   uint8
+	protoVariant uint8
+	direction    uint8
+}
+
+const (
+	afInet = 2
+
+	iocOut   = 0x40000000
+	iocIn    = 0x80000000
+	iocInOut = iocIn | iocOut
+)
+
+// ioc computes a BSD ioctl request number following the _IOC layout:
+// direction | (size << 16) | (group << 8) | num.
+func ioc(dir uintptr, group byte, num uintptr, size uintptr) uintptr {
+	return dir | (size&0x1fff)<<16 | uintptr(group)<<8 | num
+}
+
+var diocNatlook = ioc(iocInOut, 'D', 23, unsafe.Sizeof(pfiocNatlook{}))
+
+// getOriginalDST recovers the original destination address of a
+// connection redirected through pf's rdr-to/nat rules, by querying the
+// pf state table via DIOCNATLOOK on /dev/pf.
+func getOriginalDST(conn *net.TCPConn) (string, error) {
+	pf, err := os.Open("/dev/pf")
+	if err != nil {
+		return "", err
+	}
+	defer pf.Close()
+
+	local, ok1 := conn.LocalAddr().(*net.TCPAddr)
+	remote, ok2 := conn.RemoteAddr().(*net.TCPAddr)
+	if !ok1 || !ok2 {
+		return "", ErrRedirectNotSupported
+	}
+
+	var nl pfiocNatlook
+	nl.af = afInet
+	nl.proto = syscall.IPPROTO_TCP
+	nl.direction = 2 // PF_OUT
+	copy(nl.saddr.addr[:4], remote.IP.To4())
+	copy(nl.daddr.addr[:4], local.IP.To4())
+	nl.sport = htons(uint16(remote.Port))
+	nl.dport = htons(uint16(local.Port))
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, pf.Fd(), diocNatlook, uintptr(unsafe.Pointer(&nl)))
+	if errno != 0 {
+		return "", errno
+	}
+
+	ip := net.IPv4(nl.rdaddr.addr[0], nl.rdaddr.addr[1], nl.rdaddr.addr[2], nl.rdaddr.addr[3])
+	return fmt.Sprintf("%s:%d", ip.String(), ntohs(nl.rdport)), nil
+}
+
+func htons(v uint16) uint16 { return v<<8 | v>>8 }
+func ntohs(v uint16) uint16 { return v<<8 | v>>8 }
@@ -0,0 +1,64 @@
+//go:build linux
+// +build linux
+
+package gost
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+	"unsafe"
+)
+
+// soOriginalDst is SO_ORIGINAL_DST from linux/netfilter_ipv4.h, used to
+// recover the pre-NAT destination of a connection redirected by an
+// iptables REDIRECT or TPROXY rule.
+const soOriginalDst = 80
+
+// rawSockaddrIn mirrors struct sockaddr_in as filled in by the kernel
+// for SO_ORIGINAL_DST.
+type rawSockaddrIn struct {
+	family uint16
+	port   uint16
+	addr   [4]byte
+	zero   [8]byte
+}
+
+// getOriginalDST recovers the original destination address of a
+// connection redirected via iptables REDIRECT/TPROXY.
+func getOriginalDST(conn *net.TCPConn) (string, error) {
+	sc, err := conn.SyscallConn()
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		raw    rawSockaddrIn
+		sysErr error
+	)
+	ctrlErr := sc.Control(func(fd uintptr) {
+		size := uint32(unsafe.Sizeof(raw))
+		_, _, errno := syscall.Syscall6(
+			syscall.SYS_GETSOCKOPT,
+			fd,
+			syscall.IPPROTO_IP,
+			soOriginalDst,
+			uintptr(unsafe.Pointer(&raw)),
+			uintptr(unsafe.Pointer(&size)),
+			0,
+		)
+		if errno != 0 {
+			sysErr = errno
+		}
+	})
+	if ctrlErr != nil {
+		return "", ctrlErr
+	}
+	if sysErr != nil {
+		return "", sysErr
+	}
+
+	ip := net.IPv4(raw.addr[0], raw.addr[1], raw.addr[2], raw.addr[3])
+	port := int(raw.port>>8 | raw.port<<8&0xff00)
+	return fmt.Sprintf("%s:%d", ip.String(), port), nil
+}
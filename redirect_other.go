@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package gost
+
+import "net"
+
+// getOriginalDST is not implemented for this platform: neither
+// SO_ORIGINAL_DST nor DIOCNATLOOK are available.
+func getOriginalDST(conn *net.TCPConn) (string, error) {
+	return "", ErrRedirectNotSupported
+}
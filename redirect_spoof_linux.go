@@ -0,0 +1,33 @@
+//go:build linux
+// +build linux
+
+package gost
+
+import (
+	"net"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// dialSpoofed dials addr using src as the local (source) address, via
+// IP_TRANSPARENT, so the upstream sees the original client's IP rather
+// than the gateway's. This requires the listener's iptables TPROXY rule
+// and CAP_NET_ADMIN/root.
+func dialSpoofed(src net.Addr, addr string) (net.Conn, error) {
+	d := net.Dialer{
+		LocalAddr: src,
+		Control: func(network, address string, c syscall.RawConn) error {
+			var ctrlErr error
+			err := c.Control(func(fd uintptr) {
+				ctrlErr = unix.SetsockoptInt(int(fd), unix.SOL_IP, unix.IP_TRANSPARENT, 1)
+			})
+			if err != nil {
+				return err
+			}
+			return ctrlErr
+		},
+		Timeout: DialTimeout,
+	}
+	return d.Dial("tcp", addr)
+}
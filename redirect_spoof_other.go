@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package gost
+
+import "net"
+
+// dialSpoofed is not supported on this platform: IP_TRANSPARENT
+// source-address spoofing is a Linux-only facility.
+func dialSpoofed(src net.Addr, addr string) (net.Conn, error) {
+	return nil, ErrRedirectNotSupported
+}
@@ -0,0 +1,150 @@
+package gost
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// udpTProxySession is the NAT-style state gost keeps for one client
+// address, since UDP has no connection to hang the upstream dial on:
+// the upstream conn obtained through the chain, and the reply socket
+// used to answer the client with the original destination spoofed as
+// the source.
+type udpTProxySession struct {
+	upstream net.Conn
+	reply    *net.UDPConn
+	lastUsed time.Time
+}
+
+// UDPTProxyHandler relays UDP datagrams redirected by a TPROXY rule
+// through a Chain, using a NAT-style session table keyed by client
+// address to route replies back to the right client.
+type UDPTProxyHandler struct {
+	Chain      *Chain
+	SessionTTL time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*udpTProxySession
+}
+
+// NewUDPTProxyHandler creates a handler that forwards redirected
+// datagrams through chain. A nil or empty chain dials the original
+// destination directly.
+func NewUDPTProxyHandler(chain *Chain) *UDPTProxyHandler {
+	return &UDPTProxyHandler{
+		Chain:      chain,
+		SessionTTL: 60 * time.Second,
+		sessions:   make(map[string]*udpTProxySession),
+	}
+}
+
+// Serve reads datagrams from ln and relays them through the chain
+// until ln is closed or a read fails.
+func (h *UDPTProxyHandler) Serve(ln *UDPTProxyListener) error {
+	buf := make([]byte, 64*1024)
+	for {
+		n, from, origDst, err := ln.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+
+		payload := make([]byte, n)
+		copy(payload, buf[:n])
+		h.forward(from, origDst, payload)
+	}
+}
+
+// forward sends payload to origDst through the session for from,
+// creating the session (and its reply relay goroutine) on first use.
+func (h *UDPTProxyHandler) forward(from net.Addr, origDst string, payload []byte) {
+	session, err := h.session(from, origDst)
+	if err != nil {
+		logWarn("tproxy udp: dial failed", F("addr", origDst), F("error", err))
+		return
+	}
+
+	if _, err := session.upstream.Write(payload); err != nil {
+		logWarn("tproxy udp: write failed", F("addr", origDst), F("error", err))
+		h.closeSession(from.String())
+	}
+}
+
+func (h *UDPTProxyHandler) session(from net.Addr, origDst string) (*udpTProxySession, error) {
+	key := from.String()
+
+	h.mu.Lock()
+	s, ok := h.sessions[key]
+	h.mu.Unlock()
+	if ok {
+		s.lastUsed = time.Now()
+		return s, nil
+	}
+
+	var upstream net.Conn
+	var err error
+	if h.Chain.IsEmpty() {
+		upstream, err = net.DialTimeout("udp", origDst, DialTimeout)
+	} else {
+		upstream, err = h.Chain.Dial(origDst)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := replySocket(origDst)
+	if err != nil {
+		upstream.Close()
+		return nil, err
+	}
+
+	s = &udpTProxySession{upstream: upstream, reply: reply, lastUsed: time.Now()}
+
+	h.mu.Lock()
+	h.sessions[key] = s
+	h.mu.Unlock()
+
+	go h.relayReplies(key, from, s)
+
+	return s, nil
+}
+
+// relayReplies copies datagrams arriving on the upstream connection
+// back to the client, spoofing origDst as the reply's source, until
+// the upstream connection errors, closes, or the session goes idle
+// past SessionTTL.
+func (h *UDPTProxyHandler) relayReplies(key string, from net.Addr, s *udpTProxySession) {
+	defer h.closeSession(key)
+
+	ttl := h.SessionTTL
+	if ttl <= 0 {
+		ttl = 60 * time.Second
+	}
+
+	buf := make([]byte, 64*1024)
+	for {
+		s.upstream.SetReadDeadline(time.Now().Add(ttl))
+		n, err := s.upstream.Read(buf)
+		if err != nil {
+			return
+		}
+		if _, err := s.reply.WriteTo(buf[:n], from); err != nil {
+			logWarn("tproxy udp: reply failed", F("client", from), F("error", err))
+			return
+		}
+	}
+}
+
+func (h *UDPTProxyHandler) closeSession(key string) {
+	h.mu.Lock()
+	s, ok := h.sessions[key]
+	if ok {
+		delete(h.sessions, key)
+	}
+	h.mu.Unlock()
+
+	if ok {
+		s.upstream.Close()
+		s.reply.Close()
+	}
+}
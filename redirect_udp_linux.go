@@ -0,0 +1,130 @@
+//go:build linux
+// +build linux
+
+package gost
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// UDPTProxyListener reads UDP datagrams redirected by a TPROXY rule and
+// recovers each datagram's original destination from the
+// IP_RECVORIGDSTADDR ancillary data, so the packet can be forwarded
+// through the chain as if it had arrived addressed to that destination.
+type UDPTProxyListener struct {
+	conn *net.UDPConn
+}
+
+// NewUDPTProxyListener binds a UDP socket at addr with IP_TRANSPARENT
+// and IP_RECVORIGDSTADDR set, for use behind an iptables TPROXY rule.
+func NewUDPTProxyListener(addr string) (*UDPTProxyListener, error) {
+	laddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	lc := net.ListenConfig{
+		Control: func(network, address string, c syscall.RawConn) error {
+			var ctrlErr error
+			err := c.Control(func(fd uintptr) {
+				if e := unix.SetsockoptInt(int(fd), unix.SOL_IP, unix.IP_TRANSPARENT, 1); e != nil {
+					ctrlErr = e
+					return
+				}
+				ctrlErr = unix.SetsockoptInt(int(fd), unix.SOL_IP, unix.IP_RECVORIGDSTADDR, 1)
+			})
+			if err != nil {
+				return err
+			}
+			return ctrlErr
+		},
+	}
+	pc, err := lc.ListenPacket(nil, "udp", laddr.String())
+	if err != nil {
+		return nil, err
+	}
+	return &UDPTProxyListener{conn: pc.(*net.UDPConn)}, nil
+}
+
+// ReadFrom reads a datagram and returns its payload, the client address
+// it came from, and the original destination address recovered from
+// IP_RECVORIGDSTADDR.
+func (l *UDPTProxyListener) ReadFrom(b []byte) (n int, from net.Addr, origDst string, err error) {
+	oob := make([]byte, 64)
+	n, oobn, _, from, err := l.conn.ReadMsgUDP(b, oob)
+	if err != nil {
+		return
+	}
+
+	origDst, err = parseOrigDstCmsg(oob[:oobn])
+	return
+}
+
+// Close closes the underlying socket.
+func (l *UDPTProxyListener) Close() error {
+	return l.conn.Close()
+}
+
+// replySocket binds a transparent UDP socket local to origDst, so a
+// reply sent through it carries origDst as its source address and the
+// client accepts it as coming from the server it thinks it talked to.
+func replySocket(origDst string) (*net.UDPConn, error) {
+	laddr, err := net.ResolveUDPAddr("udp", origDst)
+	if err != nil {
+		return nil, err
+	}
+
+	lc := net.ListenConfig{
+		Control: func(network, address string, c syscall.RawConn) error {
+			var ctrlErr error
+			err := c.Control(func(fd uintptr) {
+				ctrlErr = unix.SetsockoptInt(int(fd), unix.SOL_IP, unix.IP_TRANSPARENT, 1)
+			})
+			if err != nil {
+				return err
+			}
+			return ctrlErr
+		},
+	}
+	pc, err := lc.ListenPacket(nil, "udp", laddr.String())
+	if err != nil {
+		return nil, err
+	}
+	return pc.(*net.UDPConn), nil
+}
+
+func parseOrigDstCmsg(oob []byte) (string, error) {
+	msgs, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return "", err
+	}
+	for _, m := range msgs {
+		if m.Header.Level == unix.SOL_IP && m.Header.Type == unix.IP_RECVORIGDSTADDR {
+			return parseSockaddrIn(m.Data)
+		}
+	}
+	return "", ErrRedirectNotSupported
+}
+
+// parseSockaddrIn decodes the original destination address out of the
+// raw struct sockaddr_in bytes carried by an IP_RECVORIGDSTADDR cmsg:
+// a 2-byte family, a 2-byte big-endian port, and 4 raw IPv4 octets.
+// x/sys/unix has no exported helper for parsing a sockaddr out of an
+// arbitrary byte slice (ParseSockaddrInet4 operates on syscall-filled
+// Sockaddr values, not cmsg payloads), so it's decoded by hand here.
+func parseSockaddrIn(data []byte) (string, error) {
+	const sockaddrInSize = 16 // sizeof(struct sockaddr_in)
+	if len(data) < sockaddrInSize {
+		return "", fmt.Errorf("redirect: short sockaddr_in cmsg: %d bytes", len(data))
+	}
+
+	port := binary.BigEndian.Uint16(data[2:4])
+	ip := net.IPv4(data[4], data[5], data[6], data[7])
+	return net.JoinHostPort(ip.String(), strconv.Itoa(int(port))), nil
+}
@@ -0,0 +1,29 @@
+//go:build !linux
+// +build !linux
+
+package gost
+
+import "net"
+
+// UDPTProxyListener is not implemented on this platform: TPROXY and
+// IP_RECVORIGDSTADDR are Linux-specific.
+type UDPTProxyListener struct{}
+
+// NewUDPTProxyListener always fails on this platform.
+func NewUDPTProxyListener(addr string) (*UDPTProxyListener, error) {
+	return nil, ErrRedirectNotSupported
+}
+
+func (l *UDPTProxyListener) ReadFrom(b []byte) (n int, from net.Addr, origDst string, err error) {
+	return 0, nil, "", ErrRedirectNotSupported
+}
+
+func (l *UDPTProxyListener) Close() error {
+	return nil
+}
+
+// replySocket is not implemented on this platform: spoofing a reply's
+// source address requires IP_TRANSPARENT, which is Linux-specific.
+func replySocket(origDst string) (*net.UDPConn, error) {
+	return nil, ErrRedirectNotSupported
+}
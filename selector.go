@@ -0,0 +1,92 @@
+package gost
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sync"
+)
+
+// Strategy is a named node-selection strategy that can be applied to a
+// NodeGroup.
+type Strategy string
+
+const (
+	// RandomStrategy picks a node uniformly at random. This is the
+	// default strategy.
+	RandomStrategy Strategy = "random"
+	// RoundRobinStrategy cycles through the available nodes in order.
+	RoundRobinStrategy Strategy = "roundrobin"
+	// HashStrategy uses rendezvous (highest random weight) hashing over
+	// a caller-supplied key, so the same key always maps to the same
+	// node as long as that node stays available, and adding or removing
+	// a node only remaps the keys that hashed to it.
+	HashStrategy Strategy = "hash"
+)
+
+// SetStrategy configures the group to select nodes using the named
+// strategy.
+func (g *NodeGroup) SetStrategy(strategy Strategy) {
+	switch strategy {
+	case RoundRobinStrategy:
+		g.SetSelector(new(roundRobinSelector))
+	case HashStrategy:
+		g.SetSelector(new(hashSelector))
+	default:
+		g.SetSelector(new(randomSelector))
+	}
+}
+
+type roundRobinSelector struct {
+	mu  sync.Mutex
+	idx uint64
+}
+
+func (s *roundRobinSelector) Select(nodes []Node, key string) (Node, error) {
+	if len(nodes) == 0 {
+		return Node{}, ErrNoAvailableNode
+	}
+
+	s.mu.Lock()
+	i := s.idx % uint64(len(nodes))
+	s.idx++
+	s.mu.Unlock()
+
+	return nodes[i], nil
+}
+
+// hashSelector implements rendezvous (HRW) hashing: for a given key,
+// every node is scored by hash(key, node.Addr) and the highest-scoring
+// node wins. Removing or adding a node only changes the winner for the
+// keys that hashed to that node, unlike modulo hashing.
+type hashSelector struct{}
+
+func (s *hashSelector) Select(nodes []Node, key string) (Node, error) {
+	if len(nodes) == 0 {
+		return Node{}, ErrNoAvailableNode
+	}
+	if key == "" {
+		// no affinity key: fall back to random selection.
+		return nodes[rand.Intn(len(nodes))], nil
+	}
+
+	var (
+		best       Node
+		bestWeight uint64
+	)
+	for i, node := range nodes {
+		w := rendezvousWeight(key, node.Addr)
+		if i == 0 || w > bestWeight {
+			bestWeight = w
+			best = node
+		}
+	}
+	return best, nil
+}
+
+func rendezvousWeight(key, node string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(node))
+	return h.Sum64()
+}
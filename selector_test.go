@@ -0,0 +1,94 @@
+package gost
+
+import "testing"
+
+func TestRoundRobinSelectorCyclesInOrder(t *testing.T) {
+	s := new(roundRobinSelector)
+	nodes := []Node{NewNode("a:1"), NewNode("b:1"), NewNode("c:1")}
+
+	for i := 0; i < len(nodes)*2; i++ {
+		node, err := s.Select(nodes, "")
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		want := nodes[i%len(nodes)].Addr
+		if node.Addr != want {
+			t.Fatalf("iteration %d: got %q, want %q", i, node.Addr, want)
+		}
+	}
+}
+
+func TestRoundRobinSelectorNoAvailableNode(t *testing.T) {
+	s := new(roundRobinSelector)
+	if _, err := s.Select(nil, ""); err != ErrNoAvailableNode {
+		t.Fatalf("expected ErrNoAvailableNode, got %v", err)
+	}
+}
+
+func TestHashSelectorIsStableForAGivenKey(t *testing.T) {
+	s := new(hashSelector)
+	nodes := []Node{NewNode("a:1"), NewNode("b:1"), NewNode("c:1")}
+
+	first, err := s.Select(nodes, "session-42")
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		node, err := s.Select(nodes, "session-42")
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		if node.Addr != first.Addr {
+			t.Fatalf("hash selection for the same key must be stable, got %q then %q", first.Addr, node.Addr)
+		}
+	}
+}
+
+// TestHashSelectorOnlyRemapsKeysOnRemovedNode is the defining property
+// of rendezvous hashing: removing a node must only change the winner
+// for keys that hashed to that node, not reshuffle every key the way
+// modulo hashing would.
+func TestHashSelectorOnlyRemapsKeysOnRemovedNode(t *testing.T) {
+	s := new(hashSelector)
+	full := []Node{NewNode("a:1"), NewNode("b:1"), NewNode("c:1"), NewNode("d:1")}
+
+	keys := []string{"k0", "k1", "k2", "k3", "k4", "k5", "k6", "k7"}
+	before := make(map[string]string, len(keys))
+	for _, k := range keys {
+		node, err := s.Select(full, k)
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		before[k] = node.Addr
+	}
+
+	reduced := full[:len(full)-1] // drop "d:1"
+	remapped := 0
+	for _, k := range keys {
+		node, err := s.Select(reduced, k)
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		if before[k] == "d:1" {
+			continue // this key had to move somewhere
+		}
+		if node.Addr != before[k] {
+			remapped++
+		}
+	}
+	if remapped != 0 {
+		t.Fatalf("removing a node remapped %d keys that weren't assigned to it", remapped)
+	}
+}
+
+func TestHashSelectorEmptyKeyFallsBackToRandom(t *testing.T) {
+	s := new(hashSelector)
+	nodes := []Node{NewNode("a:1")}
+	node, err := s.Select(nodes, "")
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if node.Addr != "a:1" {
+		t.Fatalf("got %q, want a:1", node.Addr)
+	}
+}